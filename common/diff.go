@@ -0,0 +1,60 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+package common
+
+import "strings"
+
+// LineDiff returns a minimal line-based diff between oldText and newText:
+// unchanged lines are prefixed with a space, removed lines with '-', and
+// added lines with '+'. It is meant for rendering small config/dns/routing
+// revisions to a user, not as a general-purpose diff algorithm.
+func LineDiff(oldText, newText string) string {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+	n, m := len(a), len(b)
+
+	// dp[i][j] = length of the LCS of a[i:] and b[j:].
+	dp := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				dp[i][j] = dp[i+1][j+1] + 1
+			case dp[i+1][j] >= dp[i][j+1]:
+				dp[i][j] = dp[i+1][j]
+			default:
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		lines = append(lines, "-"+a[i])
+	}
+	for ; j < m; j++ {
+		lines = append(lines, "+"+b[j])
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,28 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+package common
+
+import "context"
+
+type userContextKey struct{}
+
+// WithUser returns a context carrying the authenticated user's identity, to
+// be read back with UserFromContext. It is meant to be called by the auth
+// middleware that terminates a request before it reaches a resolver; that
+// middleware lives outside this series (no auth middleware exists in this
+// tree yet), so until it's wired in and calls WithUser, every resolver that
+// records Author via UserFromContext sees "".
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user set by WithUser, or "" if
+// none was set (e.g. auth is disabled, the middleware hasn't been added yet,
+// or the call is not request-scoped).
+func UserFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userContextKey{}).(string)
+	return user
+}
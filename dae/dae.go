@@ -7,6 +7,7 @@ package dae
 
 import (
 	"fmt"
+	"github.com/daeuniverse/dae-wing/notifier"
 	daeConfig "github.com/daeuniverse/dae/config"
 	"github.com/daeuniverse/dae/control"
 	"github.com/daeuniverse/dae/pkg/config_parser"
@@ -19,6 +20,12 @@ import (
 type ReloadMessage struct {
 	Config   *daeConfig.Config
 	Callback chan<- bool
+	/* dae-wing start */
+	// ConfigVersion, DnsVersion and RoutingVersion identify which db rows
+	// Config was built from. They are optional (zero when not applicable,
+	// e.g. EmptyConfig) and are only used to enrich notifier.ReloadEvent.
+	ConfigVersion, DnsVersion, RoutingVersion uint32
+	/* dae-wing end */
 }
 
 var ChReloadConfigs = make(chan *ReloadMessage, 16)
@@ -42,7 +49,7 @@ func Run(log *logrus.Logger, conf *daeConfig.Config, externGeoDataDirs []string,
 	if dry {
 		log.Infoln("Dry run in api-only mode")
 	dryLoop:
-		for newConf := range ChReloadConfigs {
+		for newConf := range chReload {
 			switch newConf {
 			case nil:
 				break dryLoop
@@ -58,6 +65,9 @@ func Run(log *logrus.Logger, conf *daeConfig.Config, externGeoDataDirs []string,
 	if err != nil {
 		return err
 	}
+	/* dae-wing start */
+	go StartNodeHealthProbe(0, GracefullyExit)
+	/* dae-wing end */
 
 	// Serve tproxy TCP/UDP server util signals.
 	var listener *control.Listener
@@ -77,9 +87,11 @@ func Run(log *logrus.Logger, conf *daeConfig.Config, externGeoDataDirs []string,
 	/* dae-wing start */
 	isRollback := false
 	var chCallback chan<- bool
+	var reloadErr error
+	var curConfigVersion, curDnsVersion, curRoutingVersion uint32
 	/* dae-wing end */
 loop:
-	for newReloadMsg := range ChReloadConfigs {
+	for newReloadMsg := range chReload {
 		switch newReloadMsg {
 		case nil:
 			// We will receive nil after control plane being Closed.
@@ -99,10 +111,17 @@ loop:
 				<-readyChan
 				log.Warnln("[Reload] Finished")
 				/* dae-wing start */
-				if !isRollback {
-					// To notify the success.
-					chCallback <- true
-				}
+				notifier.PublishReload(&notifier.ReloadEvent{
+					Success:        !isRollback,
+					RolledBack:     isRollback,
+					Err:            reloadErr,
+					ConfigVersion:  curConfigVersion,
+					DnsVersion:     curDnsVersion,
+					RoutingVersion: curRoutingVersion,
+				})
+				// Notify the waiter either way; on rollback the requested
+				// reload failed even though the control plane is back up.
+				chCallback <- !isRollback
 				/* dae-wing end */
 			} else {
 				// Listening error.
@@ -129,6 +148,9 @@ loop:
 			log.Warnln("[Reload] Load new control plane")
 			newC, err := newControlPlane(log, obj, dnsCache, newConf, externGeoDataDirs)
 			if err != nil {
+				/* dae-wing start */
+				origErr := err
+				/* dae-wing end */
 				log.WithFields(logrus.Fields{
 					"err": err,
 				}).Errorln("[Reload] Failed to reload; try to roll back configuration")
@@ -143,10 +165,18 @@ loop:
 				}
 				newConf = conf
 				log.Errorln("[Reload] Last reload failed; rolled back configuration")
+				/* dae-wing start */
+				isRollback = true
+				reloadErr = origErr
+				/* dae-wing end */
 			} else {
 				log.Warnln("[Reload] Stopped old control plane")
 				/* dae-wing start */
 				isRollback = false
+				reloadErr = nil
+				curConfigVersion = newReloadMsg.ConfigVersion
+				curDnsVersion = newReloadMsg.DnsVersion
+				curRoutingVersion = newReloadMsg.RoutingVersion
 				/* dae-wing end */
 			}
 
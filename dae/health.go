@@ -0,0 +1,209 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+package dae
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/daeuniverse/dae-wing/db"
+	"github.com/daeuniverse/dae-wing/notifier"
+)
+
+// defaultNodeHealthProbeInterval is how often NodeHealthProbe is polled.
+const defaultNodeHealthProbeInterval = 10 * time.Second
+
+// nodeDialTimeout bounds how long probeNodes waits for a single node to
+// answer a TCP dial.
+const nodeDialTimeout = 3 * time.Second
+
+// NodeHealthProbe is polled periodically by StartNodeHealthProbe and its
+// result is fanned out via notifier.PublishNodeStatus. It defaults to
+// probeNodes but can be swapped out (e.g. in tests, or for a probe that
+// understands a specific proxy protocol's handshake instead of a bare TCP
+// dial).
+var NodeHealthProbe func() []*notifier.NodeStatus = probeNodes
+
+// StartNodeHealthProbe polls NodeHealthProbe every interval (or
+// defaultNodeHealthProbeInterval if interval is 0) and publishes a node's
+// status whenever it changes, until stop is closed. It is a no-op while
+// NodeHealthProbe is nil, and skips probing entirely while nodeStatusChanged
+// has no subscribers, since nothing would see the result anyway.
+func StartNodeHealthProbe(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = defaultNodeHealthProbeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := make(map[uint]*notifier.NodeStatus)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			probe := NodeHealthProbe
+			if probe == nil || notifier.NodeStatusSubscriberCount() == 0 {
+				continue
+			}
+			for _, status := range probe() {
+				if statusUnchanged(last[status.NodeID], status) {
+					continue
+				}
+				last[status.NodeID] = status
+				notifier.PublishNodeStatus(status)
+			}
+		}
+	}
+}
+
+// statusUnchanged reports whether status matches prev in every way a
+// subscriber would care about; it is false when prev is nil. LastSeen is
+// excluded since it advances every tick regardless of whether anything
+// actually changed.
+func statusUnchanged(prev, status *notifier.NodeStatus) bool {
+	if prev == nil {
+		return false
+	}
+	if (prev.Online == nil) != (status.Online == nil) {
+		return false
+	}
+	if prev.Online != nil && status.Online != nil && *prev.Online != *status.Online {
+		return false
+	}
+	return prev.Latency == status.Latency
+}
+
+// probeNodes TCP-dials every known node's host:port and reports whether it
+// is reachable and how long the handshake took. This is a reachability
+// probe, not a full proxy-protocol check -- a node can answer TCP and still
+// be a broken proxy -- but it is enough to surface "this node fell off the
+// internet" to nodeStatusChanged subscribers without needing a live reload
+// to inspect. A node whose link doesn't decode into a dialable host:port
+// (e.g. unsupported link syntax) is reported with Online == nil ("unknown")
+// rather than false, since no dial was actually attempted.
+func probeNodes() []*notifier.NodeStatus {
+	var nodes []db.Node
+	if err := db.DB(context.Background()).Find(&nodes).Error; err != nil {
+		return nil
+	}
+	now := time.Now().Unix()
+	statuses := make([]*notifier.NodeStatus, 0, len(nodes))
+	for _, n := range nodes {
+		status := &notifier.NodeStatus{NodeID: n.ID, LastSeen: now}
+		if host, err := nodeHost(n.Link); err == nil {
+			start := time.Now()
+			online := false
+			if conn, err := net.DialTimeout("tcp", host, nodeDialTimeout); err == nil {
+				online = true
+				status.Latency = time.Since(start).Milliseconds()
+				conn.Close()
+			}
+			status.Online = &online
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// nodeHost extracts the host:port to dial from a node link. Most schemes
+// (trojan, vless, ss SIP002) carry it as a plain user@host:port authority
+// that net/url already parses; vmess and legacy fully-base64 ss links
+// encode it inside a base64 blob instead and need decoding first.
+func nodeHost(link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	var host string
+	switch {
+	case u.Host != "" && hasPort(u.Host):
+		// trojan://uuid@host:port, vless://uuid@host:port, ss SIP002
+		// (ss://base64(method:password)@host:port), all parse straight.
+		host = u.Host
+	case strings.EqualFold(u.Scheme, "vmess"):
+		host, err = vmessHost(link)
+	case strings.EqualFold(u.Scheme, "ss"):
+		host, err = legacyShadowsocksHost(link)
+	default:
+		return "", fmt.Errorf("no host:port in node link (scheme %q)", u.Scheme)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !hasPort(host) {
+		return "", fmt.Errorf("no port in node link %q", link)
+	}
+	return host, nil
+}
+
+func hasPort(host string) bool {
+	_, _, err := net.SplitHostPort(host)
+	return err == nil
+}
+
+// vmessHost decodes a "vmess://<base64 json>[?...][#...]" link and returns
+// the "add"/"port" fields of its JSON payload as a host:port.
+func vmessHost(link string) (string, error) {
+	payload := strings.TrimPrefix(link, "vmess://")
+	if i := strings.IndexAny(payload, "?#"); i >= 0 {
+		payload = payload[:i]
+	}
+	raw, err := decodeBase64Loose(payload)
+	if err != nil {
+		return "", fmt.Errorf("decode vmess link: %w", err)
+	}
+	var v struct {
+		Add  string `json:"add"`
+		Port any    `json:"port"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", fmt.Errorf("parse vmess link: %w", err)
+	}
+	port := ""
+	if v.Port != nil {
+		port = fmt.Sprintf("%v", v.Port)
+	}
+	if v.Add == "" || port == "" {
+		return "", fmt.Errorf("vmess link missing add/port")
+	}
+	return net.JoinHostPort(v.Add, port), nil
+}
+
+// legacyShadowsocksHost decodes a fully-base64 "ss://base64(method:password@host:port)"
+// link, as opposed to the SIP002 "ss://base64(method:password)@host:port"
+// form that net/url already parses.
+func legacyShadowsocksHost(link string) (string, error) {
+	payload := strings.TrimPrefix(link, "ss://")
+	if i := strings.IndexAny(payload, "?#"); i >= 0 {
+		payload = payload[:i]
+	}
+	raw, err := decodeBase64Loose(payload)
+	if err != nil {
+		return "", fmt.Errorf("decode ss link: %w", err)
+	}
+	at := strings.LastIndex(string(raw), "@")
+	if at < 0 {
+		return "", fmt.Errorf("legacy ss link missing host")
+	}
+	return string(raw[at+1:]), nil
+}
+
+// decodeBase64Loose decodes s as base64, accepting both standard and
+// URL-safe alphabets with or without padding -- node links are not
+// consistent about which variant they use.
+func decodeBase64Loose(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
@@ -0,0 +1,126 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+package dae
+
+import (
+	"os"
+	"time"
+
+	daeConfig "github.com/daeuniverse/dae/config"
+)
+
+// defaultReloadDebounce is the batch window used to coalesce bursts of
+// ChReloadConfigs requests into a single reload. It can be overridden with
+// the DAE_WING_RELOAD_DEBOUNCE env var (e.g. "500ms").
+const defaultReloadDebounce = 200 * time.Millisecond
+
+// chReload is the channel the reload loop in Run actually consumes. External
+// callers still publish to ChReloadConfigs; coalesceReloads sits between the
+// two so a burst of requests collapses into one reload.
+var chReload = make(chan *ReloadMessage, 16)
+
+func init() {
+	go coalesceReloads(reloadDebounce(), ChReloadConfigs, chReload)
+}
+
+func reloadDebounce() time.Duration {
+	if s := os.Getenv("DAE_WING_RELOAD_DEBOUNCE"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d
+		}
+	}
+	return defaultReloadDebounce
+}
+
+// coalesceReloads reads reload requests from in and forwards at most one
+// outstanding reload to out at a time: requests arriving within window of
+// the first one in a batch (and any arriving while a previously dispatched
+// reload is still in flight) are collapsed to the Config of the last
+// request received, and every caller coalesced into a batch is fanned the
+// same result once that single reload finishes. This is what lets a burst
+// of mutations (e.g. a subscription refresh touching many groups followed
+// by a Select) rebuild the control plane once instead of once per mutation,
+// without ever handing Run a second reload before the first one's
+// Serve/nil handshake has completed.
+//
+// nil is used as an internal exit/serve signal, most importantly as the
+// completion notice for whichever reload out is currently waiting on; it is
+// never batched and is always forwarded immediately so Run can make
+// progress and eventually resolve that reload's callback.
+func coalesceReloads(window time.Duration, in <-chan *ReloadMessage, out chan<- *ReloadMessage) {
+	defer close(out)
+
+	// The batch being accumulated for the next reload not yet dispatched.
+	var nextConf *daeConfig.Config
+	var nextConfigVersion, nextDnsVersion, nextRoutingVersion uint32
+	var nextCallbacks []chan<- bool
+	var timerC <-chan time.Time
+
+	// The reload currently dispatched to out, if any, and who is waiting
+	// on its result.
+	var fanin chan bool
+	var inFlightCallbacks []chan<- bool
+
+	dispatch := func() {
+		fanin = make(chan bool, 1)
+		inFlightCallbacks = nextCallbacks
+		out <- &ReloadMessage{
+			Config:         nextConf,
+			Callback:       fanin,
+			ConfigVersion:  nextConfigVersion,
+			DnsVersion:     nextDnsVersion,
+			RoutingVersion: nextRoutingVersion,
+		}
+		nextConf = nil
+		nextCallbacks = nil
+		timerC = nil
+	}
+
+	for {
+		var finCh chan bool
+		if fanin != nil {
+			finCh = fanin
+		}
+		select {
+		case msg, ok := <-in:
+			if !ok {
+				return
+			}
+			if msg == nil {
+				out <- nil
+				continue
+			}
+			nextConf = msg.Config
+			nextConfigVersion = msg.ConfigVersion
+			nextDnsVersion = msg.DnsVersion
+			nextRoutingVersion = msg.RoutingVersion
+			nextCallbacks = append(nextCallbacks, msg.Callback)
+			if timerC == nil {
+				timerC = time.After(window)
+			}
+		case <-timerC:
+			timerC = nil
+			// Only dispatch if nothing else is in flight; otherwise this
+			// batch waits for the in-flight reload to resolve below.
+			if fanin == nil {
+				dispatch()
+			}
+		case ok := <-finCh:
+			fanin = nil
+			for _, cb := range inFlightCallbacks {
+				cb <- ok
+			}
+			inFlightCallbacks = nil
+			// The debounce window for the next batch may already have
+			// elapsed while we were waiting (timerC == nil above); if it
+			// hasn't, the pending <-timerC case will dispatch it once it
+			// fires, since fanin is nil by then.
+			if nextConf != nil && timerC == nil {
+				dispatch()
+			}
+		}
+	}
+}
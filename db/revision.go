@@ -0,0 +1,128 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RevisionRetention is how many revisions are kept per config/dns/routing
+// row; older ones are pruned whenever a new revision is appended. Zero or
+// negative disables pruning.
+var RevisionRetention = 50
+
+// ConfigRevision is a historical snapshot of a db.Config's Global body,
+// appended every time the config is created or updated so a bad edit can be
+// rolled back.
+type ConfigRevision struct {
+	ID        uint `gorm:"primarykey"`
+	ParentID  uint `gorm:"index;not null"`
+	Version   uint32
+	Body      string
+	Author    string
+	Note      string
+	CreatedAt time.Time
+}
+
+// RoutingRevision is a historical snapshot of a db.Routing's Routing body.
+type RoutingRevision struct {
+	ID        uint `gorm:"primarykey"`
+	ParentID  uint `gorm:"index;not null"`
+	Version   uint32
+	Body      string
+	Author    string
+	Note      string
+	CreatedAt time.Time
+}
+
+// DnsRevision is a historical snapshot of a db.Dns's Dns body.
+type DnsRevision struct {
+	ID        uint `gorm:"primarykey"`
+	ParentID  uint `gorm:"index;not null"`
+	Version   uint32
+	Body      string
+	Author    string
+	Note      string
+	CreatedAt time.Time
+}
+
+// AutoMigrateRevisions migrates the three revision tables added in this
+// file. It must be called alongside the rest of the schema's AutoMigrate
+// (wherever the *gorm.DB connection is first opened) -- without it the
+// tables don't exist, and since config/dns/routing Create and Update insert
+// a revision in the same transaction as the row write, every one of those
+// calls fails, not just history.
+func AutoMigrateRevisions(d *gorm.DB) error {
+	return d.AutoMigrate(&ConfigRevision{}, &RoutingRevision{}, &DnsRevision{})
+}
+
+// PruneConfigRevisions deletes ConfigRevision rows for parentID beyond
+// RevisionRetention, keeping the most recent ones.
+func PruneConfigRevisions(tx *gorm.DB, parentID uint) error {
+	return pruneRevisions(tx, &ConfigRevision{}, parentID)
+}
+
+// PruneRoutingRevisions deletes RoutingRevision rows for parentID beyond
+// RevisionRetention, keeping the most recent ones.
+func PruneRoutingRevisions(tx *gorm.DB, parentID uint) error {
+	return pruneRevisions(tx, &RoutingRevision{}, parentID)
+}
+
+// PruneDnsRevisions deletes DnsRevision rows for parentID beyond
+// RevisionRetention, keeping the most recent ones.
+func PruneDnsRevisions(tx *gorm.DB, parentID uint) error {
+	return pruneRevisions(tx, &DnsRevision{}, parentID)
+}
+
+// FetchRevisionPage fetches up to first revisions of M (one of
+// ConfigRevision, RoutingRevision, DnsRevision) for parentID, newest first,
+// starting strictly before the after cursor (version number) when given; a
+// first of zero or less applies no limit and fetches every such revision. It
+// returns one extra row beyond the page, if available, so the caller can
+// still diff the oldest revision in the page against the revision
+// immediately before it instead of "" at the page boundary; n is the number
+// of rows that belong in the page itself.
+func FetchRevisionPage[M any](tx *gorm.DB, parentID uint, first int32, after *int32) (rows []*M, n int, err error) {
+	q := tx.Model(new(M)).
+		Where("parent_id = ?", parentID).
+		Order("version desc")
+	if after != nil {
+		q = q.Where("version < ?", *after)
+	}
+	fetch := 0
+	if first > 0 {
+		fetch = int(first) + 1
+		q = q.Limit(fetch)
+	}
+	if err = q.Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	n = len(rows)
+	if fetch > 0 && n > int(first) {
+		n = int(first)
+	}
+	return rows, n, nil
+}
+
+func pruneRevisions(tx *gorm.DB, model interface{}, parentID uint) error {
+	if RevisionRetention <= 0 {
+		return nil
+	}
+	var staleIDs []uint
+	if err := tx.Model(model).
+		Where("parent_id = ?", parentID).
+		Order("version desc").
+		Offset(RevisionRetention).
+		Pluck("id", &staleIDs).Error; err != nil {
+		return err
+	}
+	if len(staleIDs) == 0 {
+		return nil
+	}
+	return tx.Model(model).Where("id in ?", staleIDs).Delete(model).Error
+}
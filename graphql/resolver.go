@@ -0,0 +1,75 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+// Package graphql assembles the service packages under graphql/service into
+// the root resolver and schema actually handed to graphql-go. This file
+// covers the Subscription root and the config/dns/routing rollback/history
+// fields added in schema.graphqls; the rest of the Query/Mutation root
+// (group/node/subscription CRUD, etc.) is assembled the same way alongside
+// the rest of the server bootstrap.
+package graphql
+
+import (
+	"context"
+
+	"github.com/daeuniverse/dae-wing/graphql/service/config"
+	"github.com/daeuniverse/dae-wing/graphql/service/dns"
+	"github.com/daeuniverse/dae-wing/graphql/service/routing"
+	"github.com/daeuniverse/dae-wing/graphql/service/subscription"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Resolver is embedded into the server's root GraphQL resolver so its
+// Subscription methods (RunningStatusChanged, ReloadEvent,
+// NodeStatusChanged) and the rollback/history methods below are promoted to
+// satisfy schema.graphqls.
+type Resolver struct {
+	subscription.Resolver
+}
+
+func (r *Resolver) ConfigRollback(ctx context.Context, args struct {
+	ID        graphql.ID
+	ToVersion int32
+}) (*config.Resolver, error) {
+	return config.Rollback(ctx, args.ID, args.ToVersion)
+}
+
+func (r *Resolver) DnsRollback(ctx context.Context, args struct {
+	ID        graphql.ID
+	ToVersion int32
+}) (*dns.Resolver, error) {
+	return dns.Rollback(ctx, args.ID, args.ToVersion)
+}
+
+func (r *Resolver) RoutingRollback(ctx context.Context, args struct {
+	ID        graphql.ID
+	ToVersion int32
+}) (*routing.Resolver, error) {
+	return routing.Rollback(ctx, args.ID, args.ToVersion)
+}
+
+func (r *Resolver) ConfigHistory(ctx context.Context, args struct {
+	ID    graphql.ID
+	First int32
+	After *int32
+}) ([]*config.RevisionResolver, error) {
+	return config.History(ctx, args.ID, args.First, args.After)
+}
+
+func (r *Resolver) DnsHistory(ctx context.Context, args struct {
+	ID    graphql.ID
+	First int32
+	After *int32
+}) ([]*dns.RevisionResolver, error) {
+	return dns.History(ctx, args.ID, args.First, args.After)
+}
+
+func (r *Resolver) RoutingHistory(ctx context.Context, args struct {
+	ID    graphql.ID
+	First int32
+	After *int32
+}) ([]*routing.RevisionResolver, error) {
+	return routing.History(ctx, args.ID, args.First, args.After)
+}
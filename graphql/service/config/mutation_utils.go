@@ -18,6 +18,7 @@ import (
 	"github.com/daeuniverse/dae-wing/dae"
 	"github.com/daeuniverse/dae-wing/db"
 	"github.com/daeuniverse/dae-wing/graphql/service/config/global"
+	"github.com/daeuniverse/dae-wing/notifier"
 	daeConfig "github.com/daeuniverse/dae/config"
 	"github.com/daeuniverse/dae/pkg/config_parser"
 	"github.com/graph-gophers/graphql-go"
@@ -44,7 +45,23 @@ func Create(ctx context.Context, name string, glob *global.Input) (*Resolver, er
 	if err != nil {
 		return nil, err
 	}
-	if err = db.DB(ctx).Create(&m).Error; err != nil {
+	tx := db.BeginTx(ctx)
+	defer func() {
+		if err == nil {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+	if err = tx.Create(&m).Error; err != nil {
+		return nil, err
+	}
+	if err = tx.Create(&db.ConfigRevision{
+		ParentID: m.ID,
+		Version:  m.Version,
+		Body:     m.Global,
+		Author:   common.UserFromContext(ctx),
+	}).Error; err != nil {
 		return nil, err
 	}
 	return &Resolver{
@@ -67,7 +84,7 @@ func Update(ctx context.Context, _id graphql.ID, inputGlobal global.Input) (*Res
 		}
 	}()
 	var m db.Config
-	if err = tx.Model(&db.Config{}).Where("id = ?", id).First(&m).Error; err != nil {
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&db.Config{}).Where("id = ?", id).First(&m).Error; err != nil {
 		return nil, err
 	}
 	// Prepare to partially update.
@@ -83,13 +100,26 @@ func Update(ctx context.Context, _id graphql.ID, inputGlobal global.Input) (*Res
 	if err = marshaller.MarshalSection("global", reflect.ValueOf(c.Global), 0); err != nil {
 		return nil, err
 	}
+	newGlobal := string(marshaller.Bytes())
+	newVersion := m.Version + 1
 	// Update.
 	if err = tx.Model(&db.Config{ID: id}).Updates(map[string]interface{}{
-		"global":  string(marshaller.Bytes()),
-		"version": gorm.Expr("version + 1"),
+		"global":  newGlobal,
+		"version": newVersion,
 	}).Error; err != nil {
 		return nil, err
 	}
+	if err = tx.Create(&db.ConfigRevision{
+		ParentID: id,
+		Version:  newVersion,
+		Body:     newGlobal,
+		Author:   common.UserFromContext(ctx),
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = db.PruneConfigRevisions(tx, id); err != nil {
+		return nil, err
+	}
 	return &Resolver{
 		DaeGlobal: &c.Global,
 		Model:     &m,
@@ -268,6 +298,7 @@ func Run(d *gorm.DB, noLoad bool) (n int32, err error) {
 		}).Error; err != nil {
 			return 0, err
 		}
+		notifier.PublishRunningStatus(&notifier.RunningStatus{Running: false})
 		return 1, nil
 	}
 
@@ -415,8 +446,11 @@ func Run(d *gorm.DB, noLoad bool) (n int32, err error) {
 	/// Reload with current config.
 	chReloadCallback := make(chan error)
 	dae.ChReloadConfigs <- &dae.ReloadMessage{
-		Config:   c,
-		Callback: chReloadCallback,
+		Config:         c,
+		Callback:       chReloadCallback,
+		ConfigVersion:  mConfig.Version,
+		DnsVersion:     mDns.Version,
+		RoutingVersion: mRouting.Version,
 	}
 	errReload := <-chReloadCallback
 	if errReload != nil {
@@ -447,6 +481,120 @@ func Run(d *gorm.DB, noLoad bool) (n int32, err error) {
 	if err = d.Model(&sys).Association("RunningGroups").Replace(groups); err != nil {
 		return 0, err
 	}
+	notifier.PublishRunningStatus(&notifier.RunningStatus{
+		Running:               true,
+		RunningConfigID:       mConfig.ID,
+		RunningConfigVersion:  mConfig.Version,
+		RunningDnsID:          mDns.ID,
+		RunningDnsVersion:     mDns.Version,
+		RunningRoutingID:      mRouting.ID,
+		RunningRoutingVersion: mRouting.Version,
+	})
 
 	return 1, nil
 }
+
+// Rollback restores the Global body of a historical revision as a new,
+// live version, reusing Run's existing dry-run/rollback semantics to apply
+// it immediately when the config is selected and dae is running.
+func Rollback(ctx context.Context, _id graphql.ID, toVersion int32) (*Resolver, error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.BeginTx(ctx)
+	defer func() {
+		if err == nil {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+	var m db.Config
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&db.Config{}).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, err
+	}
+	var rev db.ConfigRevision
+	if err = tx.Model(&db.ConfigRevision{}).
+		Where("parent_id = ? AND version = ?", id, toVersion).
+		First(&rev).Error; err != nil {
+		return nil, fmt.Errorf("no such revision: %w", err)
+	}
+	// Check grammar and to dae config.
+	c, err := dae.ParseConfig(&rev.Body, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bad historical config: %w", err)
+	}
+	newVersion := m.Version + 1
+	if err = tx.Model(&db.Config{ID: id}).Updates(map[string]interface{}{
+		"global":  rev.Body,
+		"version": newVersion,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = tx.Create(&db.ConfigRevision{
+		ParentID: id,
+		Version:  newVersion,
+		Body:     rev.Body,
+		Author:   common.UserFromContext(ctx),
+		Note:     fmt.Sprintf("rollback to v%v", toVersion),
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = db.PruneConfigRevisions(tx, id); err != nil {
+		return nil, err
+	}
+	if m.Selected {
+		// Check if dae is running.
+		var sys db.System
+		if err = tx.Model(&db.System{}).FirstOrCreate(&sys).Error; err != nil {
+			return nil, err
+		}
+		if sys.Running {
+			if _, err = Run(tx, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Resolver{
+		DaeGlobal: &c.Global,
+		Model:     &m,
+	}, nil
+}
+
+// History returns up to first revisions of the config named by _id, newest
+// first, starting strictly before the after cursor (version number) when
+// given. A first of zero or less returns every such revision.
+func History(ctx context.Context, _id graphql.ID, first int32, after *int32) ([]*RevisionResolver, error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return nil, err
+	}
+	revisions, n, err := db.FetchRevisionPage[db.ConfigRevision](db.DB(ctx), id, first, after)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*RevisionResolver, n)
+	for i := 0; i < n; i++ {
+		var prevBody string
+		if i+1 < len(revisions) {
+			prevBody = revisions[i+1].Body
+		}
+		resolvers[i] = &RevisionResolver{m: revisions[i], prevBody: prevBody}
+	}
+	return resolvers, nil
+}
+
+// RevisionResolver exposes a db.ConfigRevision over GraphQL, including a
+// diff against the revision immediately before it.
+type RevisionResolver struct {
+	m        *db.ConfigRevision
+	prevBody string
+}
+
+func (r *RevisionResolver) Version() int32   { return int32(r.m.Version) }
+func (r *RevisionResolver) Body() string     { return r.m.Body }
+func (r *RevisionResolver) Author() string   { return r.m.Author }
+func (r *RevisionResolver) Note() string     { return r.m.Note }
+func (r *RevisionResolver) CreatedAt() int32 { return int32(r.m.CreatedAt.Unix()) }
+func (r *RevisionResolver) Diff() string     { return common.LineDiff(r.prevBody, r.m.Body) }
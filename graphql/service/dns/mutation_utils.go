@@ -0,0 +1,275 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+package dns
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/daeuniverse/dae-wing/common"
+	"github.com/daeuniverse/dae-wing/dae"
+	"github.com/daeuniverse/dae-wing/db"
+	"github.com/daeuniverse/dae-wing/graphql/service/config"
+	"github.com/graph-gophers/graphql-go"
+	"gorm.io/gorm/clause"
+)
+
+func Create(ctx context.Context, name string, dns string) (*Resolver, error) {
+	dns = "dns {\n" + dns + "\n}"
+	m := db.Dns{
+		ID:       0,
+		Name:     name,
+		Dns:      dns,
+		Selected: false,
+	}
+	// Check grammar and to dae config.
+	c, err := dae.ParseConfig(nil, &m.Dns, nil)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.BeginTx(ctx)
+	defer func() {
+		if err == nil {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+	if err = tx.Create(&m).Error; err != nil {
+		return nil, err
+	}
+	if err = tx.Create(&db.DnsRevision{
+		ParentID: m.ID,
+		Version:  m.Version,
+		Body:     m.Dns,
+		Author:   common.UserFromContext(ctx),
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &Resolver{
+		DaeDns: &c.Dns,
+		Model:  &m,
+	}, nil
+}
+
+func Update(ctx context.Context, _id graphql.ID, dns string) (*Resolver, error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.BeginTx(ctx)
+	defer func() {
+		if err == nil {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+	var m db.Dns
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&db.Dns{}).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, err
+	}
+	// Prepare to partially update.
+	m.Dns = "dns {\n" + dns + "\n}"
+	// Parse it to check the grammar.
+	c, err := dae.ParseConfig(nil, &m.Dns, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bad current dns: %w", err)
+	}
+	newVersion := m.Version + 1
+	// Update.
+	if err = tx.Model(&db.Dns{ID: id}).Updates(map[string]interface{}{
+		"dns":     m.Dns,
+		"version": newVersion,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = tx.Create(&db.DnsRevision{
+		ParentID: id,
+		Version:  newVersion,
+		Body:     m.Dns,
+		Author:   common.UserFromContext(ctx),
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = db.PruneDnsRevisions(tx, id); err != nil {
+		return nil, err
+	}
+	return &Resolver{
+		DaeDns: &c.Dns,
+		Model:  &m,
+	}, nil
+}
+
+func Remove(ctx context.Context, _id graphql.ID) (n int32, err error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return 0, err
+	}
+	tx := db.BeginTx(ctx)
+	defer func() {
+		if err == nil {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+	m := db.Dns{ID: id}
+	q := tx.Clauses(clause.Returning{Columns: []clause.Column{{Name: "selected"}}}).
+		Select(clause.Associations).
+		Delete(&m)
+	if q.Error != nil {
+		return 0, q.Error
+	}
+	return int32(q.RowsAffected), nil
+}
+
+func Select(ctx context.Context, _id graphql.ID) (n int32, err error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return 0, err
+	}
+	tx := db.BeginTx(ctx)
+	defer func() {
+		if err == nil {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+	// Unset all selected.
+	q := tx.Model(&db.Dns{}).Where("selected = ?", true).Update("selected", false)
+	if err = q.Error; err != nil {
+		return 0, err
+	}
+	// Set selected.
+	q = tx.Model(&db.Dns{ID: id}).Update("selected", true)
+	if err = q.Error; err != nil {
+		return 0, err
+	}
+	if q.RowsAffected == 0 {
+		return 0, fmt.Errorf("no such config")
+	}
+	return 1, nil
+}
+
+func Rename(ctx context.Context, _id graphql.ID, name string) (n int32, err error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return 0, err
+	}
+	q := db.DB(ctx).Model(&db.Dns{ID: id}).
+		Update("name", name)
+	if q.Error != nil {
+		return 0, q.Error
+	}
+	return int32(q.RowsAffected), nil
+}
+
+// Rollback restores the Dns body of a historical revision as a new, live
+// version, reusing config.Run's existing dry-run/rollback semantics to
+// apply it immediately when the dns is selected and dae is running.
+func Rollback(ctx context.Context, _id graphql.ID, toVersion int32) (*Resolver, error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.BeginTx(ctx)
+	defer func() {
+		if err == nil {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+	var m db.Dns
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&db.Dns{}).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, err
+	}
+	var rev db.DnsRevision
+	if err = tx.Model(&db.DnsRevision{}).
+		Where("parent_id = ? AND version = ?", id, toVersion).
+		First(&rev).Error; err != nil {
+		return nil, fmt.Errorf("no such revision: %w", err)
+	}
+	// Parse it to check the grammar.
+	c, err := dae.ParseConfig(nil, &rev.Body, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bad historical dns: %w", err)
+	}
+	newVersion := m.Version + 1
+	if err = tx.Model(&db.Dns{ID: id}).Updates(map[string]interface{}{
+		"dns":     rev.Body,
+		"version": newVersion,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = tx.Create(&db.DnsRevision{
+		ParentID: id,
+		Version:  newVersion,
+		Body:     rev.Body,
+		Author:   common.UserFromContext(ctx),
+		Note:     fmt.Sprintf("rollback to v%v", toVersion),
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = db.PruneDnsRevisions(tx, id); err != nil {
+		return nil, err
+	}
+	if m.Selected {
+		// Check if dae is running.
+		var sys db.System
+		if err = tx.Model(&db.System{}).FirstOrCreate(&sys).Error; err != nil {
+			return nil, err
+		}
+		if sys.Running {
+			if _, err = config.Run(tx, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Resolver{
+		DaeDns: &c.Dns,
+		Model:  &m,
+	}, nil
+}
+
+// History returns up to first revisions of the dns named by _id, newest
+// first, starting strictly before the after cursor (version number) when
+// given. A first of zero or less returns every such revision.
+func History(ctx context.Context, _id graphql.ID, first int32, after *int32) ([]*RevisionResolver, error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return nil, err
+	}
+	revisions, n, err := db.FetchRevisionPage[db.DnsRevision](db.DB(ctx), id, first, after)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*RevisionResolver, n)
+	for i := 0; i < n; i++ {
+		var prevBody string
+		if i+1 < len(revisions) {
+			prevBody = revisions[i+1].Body
+		}
+		resolvers[i] = &RevisionResolver{m: revisions[i], prevBody: prevBody}
+	}
+	return resolvers, nil
+}
+
+// RevisionResolver exposes a db.DnsRevision over GraphQL, including a diff
+// against the revision immediately before it.
+type RevisionResolver struct {
+	m        *db.DnsRevision
+	prevBody string
+}
+
+func (r *RevisionResolver) Version() int32   { return int32(r.m.Version) }
+func (r *RevisionResolver) Body() string     { return r.m.Body }
+func (r *RevisionResolver) Author() string   { return r.m.Author }
+func (r *RevisionResolver) Note() string     { return r.m.Note }
+func (r *RevisionResolver) CreatedAt() int32 { return int32(r.m.CreatedAt.Unix()) }
+func (r *RevisionResolver) Diff() string     { return common.LineDiff(r.prevBody, r.m.Body) }
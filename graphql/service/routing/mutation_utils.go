@@ -12,8 +12,8 @@ import (
 	"github.com/daeuniverse/dae-wing/common"
 	"github.com/daeuniverse/dae-wing/dae"
 	"github.com/daeuniverse/dae-wing/db"
+	"github.com/daeuniverse/dae-wing/graphql/service/config"
 	"github.com/graph-gophers/graphql-go"
-	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -30,7 +30,23 @@ func Create(ctx context.Context, name string, routing string) (*Resolver, error)
 	if err != nil {
 		return nil, err
 	}
-	if err = db.DB(ctx).Create(&m).Error; err != nil {
+	tx := db.BeginTx(ctx)
+	defer func() {
+		if err == nil {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+	if err = tx.Create(&m).Error; err != nil {
+		return nil, err
+	}
+	if err = tx.Create(&db.RoutingRevision{
+		ParentID: m.ID,
+		Version:  m.Version,
+		Body:     m.Routing,
+		Author:   common.UserFromContext(ctx),
+	}).Error; err != nil {
 		return nil, err
 	}
 	return &Resolver{
@@ -53,7 +69,7 @@ func Update(ctx context.Context, _id graphql.ID, routing string) (*Resolver, err
 		}
 	}()
 	var m db.Routing
-	if err = tx.Model(&db.Routing{}).Where("id = ?", id).First(&m).Error; err != nil {
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&db.Routing{}).Where("id = ?", id).First(&m).Error; err != nil {
 		return nil, err
 	}
 	// Prepare to partially update.
@@ -63,13 +79,25 @@ func Update(ctx context.Context, _id graphql.ID, routing string) (*Resolver, err
 	if err != nil {
 		return nil, fmt.Errorf("bad current routing: %w", err)
 	}
+	newVersion := m.Version + 1
 	// Update.
 	if err = tx.Model(&db.Routing{ID: id}).Updates(map[string]interface{}{
 		"routing": m.Routing,
-		"version": gorm.Expr("version + 1"),
+		"version": newVersion,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = tx.Create(&db.RoutingRevision{
+		ParentID: id,
+		Version:  newVersion,
+		Body:     m.Routing,
+		Author:   common.UserFromContext(ctx),
 	}).Error; err != nil {
 		return nil, err
 	}
+	if err = db.PruneRoutingRevisions(tx, id); err != nil {
+		return nil, err
+	}
 	return &Resolver{
 		DaeRouting: &c.Routing,
 		Model:      &m,
@@ -140,3 +168,108 @@ func Rename(ctx context.Context, _id graphql.ID, name string) (n int32, err erro
 	}
 	return int32(q.RowsAffected), nil
 }
+
+// Rollback restores the Routing body of a historical revision as a new,
+// live version, reusing config.Run's existing dry-run/rollback semantics to
+// apply it immediately when the routing is selected and dae is running.
+func Rollback(ctx context.Context, _id graphql.ID, toVersion int32) (*Resolver, error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.BeginTx(ctx)
+	defer func() {
+		if err == nil {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+	var m db.Routing
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&db.Routing{}).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, err
+	}
+	var rev db.RoutingRevision
+	if err = tx.Model(&db.RoutingRevision{}).
+		Where("parent_id = ? AND version = ?", id, toVersion).
+		First(&rev).Error; err != nil {
+		return nil, fmt.Errorf("no such revision: %w", err)
+	}
+	// Parse it to check the grammar.
+	c, err := dae.ParseConfig(nil, nil, &rev.Body)
+	if err != nil {
+		return nil, fmt.Errorf("bad historical routing: %w", err)
+	}
+	newVersion := m.Version + 1
+	if err = tx.Model(&db.Routing{ID: id}).Updates(map[string]interface{}{
+		"routing": rev.Body,
+		"version": newVersion,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = tx.Create(&db.RoutingRevision{
+		ParentID: id,
+		Version:  newVersion,
+		Body:     rev.Body,
+		Author:   common.UserFromContext(ctx),
+		Note:     fmt.Sprintf("rollback to v%v", toVersion),
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err = db.PruneRoutingRevisions(tx, id); err != nil {
+		return nil, err
+	}
+	if m.Selected {
+		// Check if dae is running.
+		var sys db.System
+		if err = tx.Model(&db.System{}).FirstOrCreate(&sys).Error; err != nil {
+			return nil, err
+		}
+		if sys.Running {
+			if _, err = config.Run(tx, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Resolver{
+		DaeRouting: &c.Routing,
+		Model:      &m,
+	}, nil
+}
+
+// History returns up to first revisions of the routing named by _id, newest
+// first, starting strictly before the after cursor (version number) when
+// given. A first of zero or less returns every such revision.
+func History(ctx context.Context, _id graphql.ID, first int32, after *int32) ([]*RevisionResolver, error) {
+	id, err := common.DecodeCursor(_id)
+	if err != nil {
+		return nil, err
+	}
+	revisions, n, err := db.FetchRevisionPage[db.RoutingRevision](db.DB(ctx), id, first, after)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*RevisionResolver, n)
+	for i := 0; i < n; i++ {
+		var prevBody string
+		if i+1 < len(revisions) {
+			prevBody = revisions[i+1].Body
+		}
+		resolvers[i] = &RevisionResolver{m: revisions[i], prevBody: prevBody}
+	}
+	return resolvers, nil
+}
+
+// RevisionResolver exposes a db.RoutingRevision over GraphQL, including a
+// diff against the revision immediately before it.
+type RevisionResolver struct {
+	m        *db.RoutingRevision
+	prevBody string
+}
+
+func (r *RevisionResolver) Version() int32   { return int32(r.m.Version) }
+func (r *RevisionResolver) Body() string     { return r.m.Body }
+func (r *RevisionResolver) Author() string   { return r.m.Author }
+func (r *RevisionResolver) Note() string     { return r.m.Note }
+func (r *RevisionResolver) CreatedAt() int32 { return int32(r.m.CreatedAt.Unix()) }
+func (r *RevisionResolver) Diff() string     { return common.LineDiff(r.prevBody, r.m.Body) }
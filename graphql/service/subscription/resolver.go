@@ -0,0 +1,100 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+// Package subscription implements the GraphQL Subscription root fields:
+// runningStatusChanged, reloadEvent and nodeStatusChanged. Each simply
+// relays events fanned out by package notifier for as long as the client
+// stays connected.
+package subscription
+
+import (
+	"context"
+
+	"github.com/daeuniverse/dae-wing/notifier"
+)
+
+// Resolver is the GraphQL Subscription root resolver.
+type Resolver struct{}
+
+// RunningStatusChanged emits whenever db.System's running/selected-version
+// fields change.
+func (r *Resolver) RunningStatusChanged(ctx context.Context) <-chan *RunningStatusResolver {
+	events := notifier.SubscribeRunningStatus()
+	c := make(chan *RunningStatusResolver)
+	go func() {
+		defer close(c)
+		defer notifier.UnsubscribeRunningStatus(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				select {
+				case c <- &RunningStatusResolver{s: e}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return c
+}
+
+// ReloadEvent emits the result of every reload produced by the loop in
+// dae.Run, including rollbacks.
+func (r *Resolver) ReloadEvent(ctx context.Context) <-chan *ReloadEventResolver {
+	events := notifier.SubscribeReload()
+	c := make(chan *ReloadEventResolver)
+	go func() {
+		defer close(c)
+		defer notifier.UnsubscribeReload(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				select {
+				case c <- &ReloadEventResolver{e: e}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return c
+}
+
+// NodeStatusChanged emits per-node online/latency/last-seen updates
+// observed by the node-health probe in package dae.
+func (r *Resolver) NodeStatusChanged(ctx context.Context) <-chan *NodeStatusResolver {
+	events := notifier.SubscribeNodeStatus()
+	c := make(chan *NodeStatusResolver)
+	go func() {
+		defer close(c)
+		defer notifier.UnsubscribeNodeStatus(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				select {
+				case c <- &NodeStatusResolver{s: e}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return c
+}
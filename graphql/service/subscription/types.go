@@ -0,0 +1,86 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+package subscription
+
+import (
+	"github.com/daeuniverse/dae-wing/common"
+	"github.com/daeuniverse/dae-wing/notifier"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// RunningStatusResolver is returned by Resolver.RunningStatusChanged.
+type RunningStatusResolver struct {
+	s *notifier.RunningStatus
+}
+
+func (r *RunningStatusResolver) Running() bool { return r.s.Running }
+
+func (r *RunningStatusResolver) RunningConfigID() *graphql.ID {
+	return encodeCursorOrNil(r.s.RunningConfigID)
+}
+
+func (r *RunningStatusResolver) RunningConfigVersion() int32 {
+	return int32(r.s.RunningConfigVersion)
+}
+
+func (r *RunningStatusResolver) RunningDnsID() *graphql.ID {
+	return encodeCursorOrNil(r.s.RunningDnsID)
+}
+
+func (r *RunningStatusResolver) RunningDnsVersion() int32 {
+	return int32(r.s.RunningDnsVersion)
+}
+
+func (r *RunningStatusResolver) RunningRoutingID() *graphql.ID {
+	return encodeCursorOrNil(r.s.RunningRoutingID)
+}
+
+func (r *RunningStatusResolver) RunningRoutingVersion() int32 {
+	return int32(r.s.RunningRoutingVersion)
+}
+
+// encodeCursorOrNil encodes id as a relay cursor, or returns nil when id is
+// the zero value (nothing selected/running yet).
+func encodeCursorOrNil(id uint) *graphql.ID {
+	if id == 0 {
+		return nil
+	}
+	cursor := common.EncodeCursor(id)
+	return &cursor
+}
+
+// ReloadEventResolver is returned by Resolver.ReloadEvent.
+type ReloadEventResolver struct {
+	e *notifier.ReloadEvent
+}
+
+func (r *ReloadEventResolver) Success() bool    { return r.e.Success }
+func (r *ReloadEventResolver) RolledBack() bool { return r.e.RolledBack }
+
+func (r *ReloadEventResolver) Error() *string {
+	if r.e.Err == nil {
+		return nil
+	}
+	s := r.e.Err.Error()
+	return &s
+}
+
+func (r *ReloadEventResolver) ConfigVersion() int32  { return int32(r.e.ConfigVersion) }
+func (r *ReloadEventResolver) DnsVersion() int32     { return int32(r.e.DnsVersion) }
+func (r *ReloadEventResolver) RoutingVersion() int32 { return int32(r.e.RoutingVersion) }
+
+// NodeStatusResolver is returned by Resolver.NodeStatusChanged.
+type NodeStatusResolver struct {
+	s *notifier.NodeStatus
+}
+
+func (r *NodeStatusResolver) NodeID() graphql.ID { return common.EncodeCursor(r.s.NodeID) }
+
+// Online is nil when reachability couldn't be determined for this node
+// (see notifier.NodeStatus), not just when the node is unreachable.
+func (r *NodeStatusResolver) Online() *bool   { return r.s.Online }
+func (r *NodeStatusResolver) Latency() int32  { return int32(r.s.Latency) }
+func (r *NodeStatusResolver) LastSeen() int32 { return int32(r.s.LastSeen) }
@@ -0,0 +1,137 @@
+/*
+ * SPDX-License-Identifier: AGPL-3.0-only
+ * Copyright (c) 2023, daeuniverse Organization <team@v2raya.org>
+ */
+
+// Package notifier fans out dae control-plane events -- running status
+// changes, reload results, and node health -- to any number of subscribers.
+// It decouples producers (the reload loop in package dae, the node-health
+// prober) from consumers (the GraphQL subscription resolvers), so a web UI
+// can render live status instead of polling after every mutation.
+package notifier
+
+import "sync"
+
+// RunningStatus mirrors the fields of db.System that describe what is
+// currently loaded and running.
+type RunningStatus struct {
+	Running               bool
+	RunningConfigID       uint
+	RunningConfigVersion  uint32
+	RunningDnsID          uint
+	RunningDnsVersion     uint32
+	RunningRoutingID      uint
+	RunningRoutingVersion uint32
+}
+
+// ReloadEvent describes the outcome of a single (possibly coalesced) reload
+// produced by the loop in dae.Run.
+type ReloadEvent struct {
+	Success        bool
+	RolledBack     bool
+	Err            error
+	ConfigVersion  uint32
+	DnsVersion     uint32
+	RoutingVersion uint32
+}
+
+// NodeStatus describes an observed change in a node's health as seen by the
+// node-health probe in package dae. Online is nil when reachability could not be
+// determined at all (e.g. the node's link doesn't parse into a host:port),
+// as opposed to false, which means a dial was attempted and failed.
+type NodeStatus struct {
+	NodeID   uint
+	Online   *bool
+	Latency  int64 // milliseconds; meaningless unless Online is non-nil and true.
+	LastSeen int64 // unix seconds.
+}
+
+// broker is a generic, mutex-protected set of subscriber channels for a
+// single event type T. It is intentionally tiny: subscriber counts are
+// expected to be a handful of GraphQL subscriptions, not a hot path.
+type broker[T any] struct {
+	mu   sync.Mutex
+	subs map[chan T]struct{}
+}
+
+func newBroker[T any]() *broker[T] {
+	return &broker[T]{subs: make(map[chan T]struct{})}
+}
+
+func (b *broker[T]) Subscribe() chan T {
+	ch := make(chan T, 1)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *broker[T]) Unsubscribe(ch chan T) {
+	b.mu.Lock()
+	delete(b.subs, ch)
+	b.mu.Unlock()
+	close(ch)
+}
+
+func (b *broker[T]) SubscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *broker[T]) Publish(event T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		// Subscribers are responsible for keeping up; a slow consumer only
+		// drops intermediate events instead of blocking the producer.
+		select {
+		case ch <- event:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- event
+		}
+	}
+}
+
+var (
+	runningStatusBroker = newBroker[*RunningStatus]()
+	reloadEventBroker   = newBroker[*ReloadEvent]()
+	nodeStatusBroker    = newBroker[*NodeStatus]()
+)
+
+// SubscribeRunningStatus returns a channel that receives a value whenever
+// db.System's running/selected-version fields change. Call
+// UnsubscribeRunningStatus when done, typically via defer.
+func SubscribeRunningStatus() chan *RunningStatus { return runningStatusBroker.Subscribe() }
+
+func UnsubscribeRunningStatus(ch chan *RunningStatus) { runningStatusBroker.Unsubscribe(ch) }
+
+// PublishRunningStatus notifies subscribers of a new running status.
+func PublishRunningStatus(s *RunningStatus) { runningStatusBroker.Publish(s) }
+
+// SubscribeReload returns a channel that receives a value for every reload
+// produced by the loop in dae.Run, including rollbacks.
+func SubscribeReload() chan *ReloadEvent { return reloadEventBroker.Subscribe() }
+
+func UnsubscribeReload(ch chan *ReloadEvent) { reloadEventBroker.Unsubscribe(ch) }
+
+// PublishReload notifies subscribers of a reload result.
+func PublishReload(e *ReloadEvent) { reloadEventBroker.Publish(e) }
+
+// SubscribeNodeStatus returns a channel that receives a value whenever the
+// node-health prober observes a change for any node.
+func SubscribeNodeStatus() chan *NodeStatus { return nodeStatusBroker.Subscribe() }
+
+func UnsubscribeNodeStatus(ch chan *NodeStatus) { nodeStatusBroker.Unsubscribe(ch) }
+
+// PublishNodeStatus notifies subscribers of a node status change.
+func PublishNodeStatus(s *NodeStatus) { nodeStatusBroker.Publish(s) }
+
+// NodeStatusSubscriberCount reports how many subscribers are currently
+// listening for node status updates. The node-health prober uses this to
+// skip dialing every node on a tick that nobody is watching.
+func NodeStatusSubscriberCount() int { return nodeStatusBroker.SubscriberCount() }